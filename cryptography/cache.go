@@ -0,0 +1,94 @@
+package cryptography
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is used by NewTTLCache when the caller wants the package
+// default rather than tuning it themselves.
+const DefaultCacheTTL = 30 * time.Minute
+
+// Cache is the pluggable hot-path cache TokenManager consults before
+// touching its TokenJar. A nil Cache (the zero-value TokenManager) disables
+// caching entirely.
+type Cache interface {
+	Get(key string) (value string, ok bool)
+	Set(key, value string, ttl time.Duration)
+	Delete(key string)
+}
+
+type ttlCacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// TTLCache is an in-memory Cache that expires entries after a fixed TTL,
+// checked lazily on Get rather than swept in the background.
+type TTLCache struct {
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry
+	ttl     time.Duration
+}
+
+// NewTTLCache returns an empty TTLCache. ttl <= 0 means DefaultCacheTTL.
+func NewTTLCache(ttl time.Duration) *TTLCache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &TTLCache{entries: make(map[string]ttlCacheEntry), ttl: ttl}
+}
+
+func (c *TTLCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key. ttl <= 0 uses the cache's configured TTL.
+func (c *TTLCache) Set(key, value string, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlCacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *TTLCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func cacheKey(kind TokenKind, name string) string {
+	return string(kind) + ":" + name
+}
+
+// cacheScopeKey is the context key WithoutCache sets.
+type cacheScopeKey struct{}
+
+// WithoutCache returns a context that makes the next GetOrCreateServiceTokenContext/
+// GetOrCreateUserTokenContext call bypass the cache and re-read the jar,
+// refreshing the cached value afterward. Use it right after calling
+// RevokeServiceToken/RevokeUserToken/RotateToken in the same request so a
+// stale cached token isn't served before its TTL naturally expires.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheScopeKey{}, true)
+}
+
+func bypassCache(ctx context.Context) bool {
+	v, _ := ctx.Value(cacheScopeKey{}).(bool)
+	return v
+}
@@ -0,0 +1,31 @@
+package cryptography
+
+import "testing"
+
+func BenchmarkGetOrCreateServiceToken_NoCache(b *testing.B) {
+	tm := NewTokenManager(NewMemoryTokenJar())
+	if _, err := tm.GetOrCreateServiceToken("billing"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tm.GetOrCreateServiceToken("billing"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOrCreateServiceToken_Cached(b *testing.B) {
+	tm := NewTokenManagerWithCache(NewMemoryTokenJar(), NewTTLCache(DefaultCacheTTL))
+	if _, err := tm.GetOrCreateServiceToken("billing"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tm.GetOrCreateServiceToken("billing"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package cryptography
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// SealedPrefix marks a key produced by EncryptedTokenCodec.Seal, as
+	// opposed to the plain "lkp-<service>-<user>" format TokenManager issues.
+	SealedPrefix = "lkp."
+
+	tokenKeySize = 32 // AES-256
+
+	// TokenKeyEnvVar names the environment variable holding the base64
+	// primary (sealing) key, used when Config.TokenKey is empty.
+	TokenKeyEnvVar = "LKP_TOKEN_KEY"
+	// TokenKeysEnvVar names the environment variable holding a
+	// comma-separated list of additional base64 decryption-only keys, for
+	// rotating off an older primary key without invalidating tokens already
+	// sealed under it.
+	TokenKeysEnvVar = "LKP_TOKEN_KEYS"
+)
+
+// ErrNoTokenKey is returned by LoadTokenKeys when neither Config.TokenKey nor
+// the TokenKeyEnvVar environment variable is set.
+var ErrNoTokenKey = errors.New("cryptography: no token key configured")
+
+// Payload is the data an EncryptedTokenCodec seals into a key.
+type Payload struct {
+	ServiceName string
+	UserName    string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+// EncryptedTokenCodec seals/opens Payloads with AES-GCM, so a downstream
+// service can verify a key locally without a round-trip through
+// TokenManager. The first key is used for sealing; every key is tried in
+// order when opening, so a rotated-out key can still validate tokens issued
+// under it until they expire.
+type EncryptedTokenCodec struct {
+	keys [][]byte
+}
+
+// NewEncryptedTokenCodec builds a codec from one or more AES-256 keys. keys
+// must be non-empty and every key must be exactly 32 bytes.
+func NewEncryptedTokenCodec(keys ...[]byte) (*EncryptedTokenCodec, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoTokenKey
+	}
+	for _, k := range keys {
+		if len(k) != tokenKeySize {
+			return nil, fmt.Errorf("cryptography: token key must be %d bytes, got %d", tokenKeySize, len(k))
+		}
+	}
+	return &EncryptedTokenCodec{keys: keys}, nil
+}
+
+// LoadTokenKeys resolves the sealing key from config.TokenKey (falling back
+// to TokenKeyEnvVar) and any additional decryption-only keys from
+// TokenKeysEnvVar, in rotation order: primary first, oldest last.
+func LoadTokenKeys(config *Config) ([][]byte, error) {
+	var keys [][]byte
+
+	primary := config.TokenKey
+	if primary == "" {
+		primary = os.Getenv(TokenKeyEnvVar)
+	}
+	if primary == "" {
+		return nil, ErrNoTokenKey
+	}
+	key, err := base64.StdEncoding.DecodeString(primary)
+	if err != nil {
+		return nil, fmt.Errorf("cryptography: decoding token key: %w", err)
+	}
+	keys = append(keys, key)
+
+	if extra := os.Getenv(TokenKeysEnvVar); extra != "" {
+		for _, encoded := range strings.Split(extra, ",") {
+			encoded = strings.TrimSpace(encoded)
+			if encoded == "" {
+				continue
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("cryptography: decoding rotation token key: %w", err)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoTokenKey
+	}
+	return keys, nil
+}
+
+// Seal gob-encodes payload and encrypts it with AES-GCM under the codec's
+// primary key, emitting "lkp.<base62(nonce||ciphertext)>".
+func (c *EncryptedTokenCodec) Seal(payload Payload) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(payload); err != nil {
+		return "", err
+	}
+
+	gcm, err := c.gcm(c.keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, buf.Bytes(), nil)
+	return SealedPrefix + encodeBase62(sealed), nil
+}
+
+// Open reverses Seal, trying each configured key in turn until one decrypts
+// successfully, and rejects the payload with ErrTokenExpired if its
+// ExpiresAt has passed - callers get the same TTL enforcement locally that
+// VerifyKey applies server-side, with no DB round-trip.
+func (c *EncryptedTokenCodec) Open(token string) (Payload, error) {
+	rest, ok := strings.CutPrefix(token, SealedPrefix)
+	if !ok {
+		return Payload{}, ErrMalformedKey
+	}
+
+	raw, err := decodeBase62(rest)
+	if err != nil {
+		return Payload{}, ErrMalformedKey
+	}
+
+	var lastErr error
+	for _, key := range c.keys {
+		gcm, err := c.gcm(key)
+		if err != nil {
+			return Payload{}, err
+		}
+		if len(raw) < gcm.NonceSize() {
+			lastErr = ErrMalformedKey
+			continue
+		}
+		nonce, ciphertext := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var payload Payload
+		if err := gob.NewDecoder(bytes.NewReader(plaintext)).Decode(&payload); err != nil {
+			return Payload{}, err
+		}
+		if !payload.ExpiresAt.IsZero() && time.Now().After(payload.ExpiresAt) {
+			return Payload{}, ErrTokenExpired
+		}
+		return payload, nil
+	}
+
+	if lastErr == nil {
+		lastErr = ErrMalformedKey
+	}
+	return Payload{}, lastErr
+}
+
+func (c *EncryptedTokenCodec) gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encodeBase62 renders data as a base62 string, using the same alphabet as
+// GenerateRandomToken. Leading zero bytes (which a plain big.Int encoding
+// would otherwise drop, changing the decoded length) are preserved as
+// leading Base62Chars[0] digits.
+func encodeBase62(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(data)
+	base := big.NewInt(int64(len(Base62Chars)))
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, Base62Chars[mod.Int64()])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return strings.Repeat(string(Base62Chars[0]), zeros) + string(out)
+}
+
+// decodeBase62 reverses encodeBase62.
+func decodeBase62(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == Base62Chars[0] {
+		zeros++
+	}
+
+	n := new(big.Int)
+	base := big.NewInt(int64(len(Base62Chars)))
+	for _, r := range s[zeros:] {
+		idx := strings.IndexRune(Base62Chars, r)
+		if idx < 0 {
+			return nil, fmt.Errorf("cryptography: invalid base62 character %q", r)
+		}
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+
+	out := make([]byte, zeros)
+	return append(out, n.Bytes()...), nil
+}
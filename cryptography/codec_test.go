@@ -0,0 +1,62 @@
+package cryptography
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestCodec(t *testing.T) *EncryptedTokenCodec {
+	t.Helper()
+	key := make([]byte, tokenKeySize)
+	codec, err := NewEncryptedTokenCodec(key)
+	if err != nil {
+		t.Fatalf("NewEncryptedTokenCodec: %v", err)
+	}
+	return codec
+}
+
+func TestEncryptedTokenCodecSealOpenRoundTrip(t *testing.T) {
+	codec := newTestCodec(t)
+
+	payload := Payload{ServiceName: "billing", UserName: "alice", IssuedAt: time.Now()}
+	sealed, err := codec.Seal(payload)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	got, err := codec.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got.ServiceName != payload.ServiceName || got.UserName != payload.UserName {
+		t.Fatalf("Open = %+v, want %+v", got, payload)
+	}
+}
+
+func TestEncryptedTokenCodecOpenRejectsExpired(t *testing.T) {
+	codec := newTestCodec(t)
+
+	payload := Payload{
+		ServiceName: "billing",
+		UserName:    "alice",
+		IssuedAt:    time.Now().Add(-2 * time.Hour),
+		ExpiresAt:   time.Now().Add(-time.Hour),
+	}
+	sealed, err := codec.Seal(payload)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if _, err := codec.Open(sealed); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("Open expired payload: got %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestEncryptedTokenCodecOpenRejectsMalformed(t *testing.T) {
+	codec := newTestCodec(t)
+
+	if _, err := codec.Open("not-a-sealed-key"); !errors.Is(err, ErrMalformedKey) {
+		t.Fatalf("Open malformed key: got %v, want ErrMalformedKey", err)
+	}
+}
@@ -1,13 +1,18 @@
 package cryptography
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -18,38 +23,122 @@ const (
 	Base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 )
 
+// Schema additions required on top of the original service_tokens/user_tokens
+// tables for PostgresTokenJar/VerifyKey/RevokeServiceToken/RevokeUserToken/
+// RotateToken to work:
+//
+//	ALTER TABLE service_tokens ADD COLUMN created_at          timestamptz NOT NULL DEFAULT now();
+//	ALTER TABLE service_tokens ADD COLUMN expires_at          timestamptz;
+//	ALTER TABLE service_tokens ADD COLUMN revoked_at          timestamptz;
+//	ALTER TABLE service_tokens ADD COLUMN last_used_at        timestamptz;
+//	ALTER TABLE service_tokens ADD COLUMN previous_token      text;
+//	ALTER TABLE service_tokens ADD COLUMN previous_expires_at timestamptz;
+//	ALTER TABLE service_tokens ADD COLUMN use_count           integer NOT NULL DEFAULT 0;
+//	ALTER TABLE user_tokens    ADD COLUMN created_at          timestamptz NOT NULL DEFAULT now();
+//	ALTER TABLE user_tokens    ADD COLUMN expires_at          timestamptz;
+//	ALTER TABLE user_tokens    ADD COLUMN revoked_at          timestamptz;
+//	ALTER TABLE user_tokens    ADD COLUMN last_used_at        timestamptz;
+//	ALTER TABLE user_tokens    ADD COLUMN previous_token      text;
+//	ALTER TABLE user_tokens    ADD COLUMN previous_expires_at timestamptz;
+//	ALTER TABLE user_tokens    ADD COLUMN use_count           integer NOT NULL DEFAULT 0;
+
+var (
+	// ErrTokenNotFound is returned when a presented key does not match an
+	// active service or user token.
+	ErrTokenNotFound = errors.New("cryptography: token not found")
+	// ErrTokenRevoked is returned when a presented key matches a token that
+	// has been explicitly revoked.
+	ErrTokenRevoked = errors.New("cryptography: token revoked")
+	// ErrTokenExpired is returned when a presented key matches a token past
+	// its TokenPolicy.TTL.
+	ErrTokenExpired = errors.New("cryptography: token expired")
+	// ErrMalformedKey is returned when a presented key does not match the
+	// lkp-<service>-<user> format.
+	ErrMalformedKey = errors.New("cryptography: malformed key")
+)
+
+// TokenPolicy controls the lifecycle of tokens issued by a TokenManager.
+type TokenPolicy struct {
+	// TTL is how long a token stays active after CreatedAt before VerifyKey
+	// treats it as expired. Zero means tokens never expire.
+	TTL time.Duration
+	// GracePeriod is how long a rotated-out token keeps validating after
+	// RotateToken replaces it, to cover in-flight callers.
+	GracePeriod time.Duration
+	// MaxUses caps how many times VerifyKey will accept a token before it is
+	// treated as expired. Zero means unlimited.
+	MaxUses int
+}
+
+// DefaultTokenPolicy is used by NewTokenManager when no policy is supplied.
+var DefaultTokenPolicy = TokenPolicy{TTL: 0, GracePeriod: 24 * time.Hour, MaxUses: 0}
+
 type Config struct {
 	DBHost     string `json:"db_host"`
 	DBPort     int    `json:"db_port"`
 	DBUser     string `json:"db_user"`
 	DBPassword string `json:"db_password"`
 	DBName     string `json:"db_name"`
+	// DBSSLMode is passed through to the postgres DSN's sslmode parameter.
+	// Empty means "disable", matching this package's historical default.
+	DBSSLMode string `json:"db_sslmode"`
+	// DBMaxOpenConns/DBMaxIdleConns/DBConnMaxLifetimeSeconds tune the
+	// *sql.DB pool NewClient opens. Zero leaves the database/sql default.
+	DBMaxOpenConns           int `json:"db_max_open_conns"`
+	DBMaxIdleConns           int `json:"db_max_idle_conns"`
+	DBConnMaxLifetimeSeconds int `json:"db_conn_max_lifetime_seconds"`
+
+	// TokenKey is the base64-encoded AES-256 key EncryptedTokenCodec seals
+	// keys under. If empty, LoadTokenKeys falls back to TokenKeyEnvVar.
+	TokenKey string `json:"token_key"`
 }
 
-func LoadConfig(filename string) (*Config, error) {
-	file, err := os.Open(filename)
-	if err != nil {
+// ConfigFromReader parses a JSON config document from r. Unlike LoadConfig,
+// the source doesn't have to be a file - callers can supply an embedded
+// asset, an env-populated buffer, or a Vault response body.
+func ConfigFromReader(r io.Reader) (*Config, error) {
+	var config Config
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return &config, nil
+}
 
-	var config Config
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&config)
+func LoadConfig(filename string) (*Config, error) {
+	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	return &config, nil
+	return ConfigFromReader(file)
 }
 
 type TokenManager struct {
-	db *sql.DB
-	mu sync.Mutex
+	jar    TokenJar
+	cache  Cache
+	mu     sync.Mutex
+	policy TokenPolicy
 }
 
-func NewTokenManager(db *sql.DB) *TokenManager {
-	return &TokenManager{db: db}
+// NewTokenManager builds a TokenManager around any TokenJar - PostgresTokenJar
+// for production, MemoryTokenJar for tests, or FileTokenJar for embedded/CLI
+// usage where a database is overkill.
+func NewTokenManager(jar TokenJar) *TokenManager {
+	return &TokenManager{jar: jar, policy: DefaultTokenPolicy}
+}
+
+// NewTokenManagerWithPolicy is like NewTokenManager but lets callers override
+// TTL, grace period and max-use enforcement.
+func NewTokenManagerWithPolicy(jar TokenJar, policy TokenPolicy) *TokenManager {
+	return &TokenManager{jar: jar, policy: policy}
+}
+
+// NewTokenManagerWithCache is like NewTokenManager but fronts the jar with a
+// Cache, so repeat GetOrCreateServiceToken/GetOrCreateUserToken lookups for
+// the same name return without touching the jar at all.
+func NewTokenManagerWithCache(jar TokenJar, cache Cache) *TokenManager {
+	return &TokenManager{jar: jar, cache: cache, policy: DefaultTokenPolicy}
 }
 
 func GenerateRandomToken(length int) (string, error) {
@@ -76,112 +165,462 @@ func GenerateRandomToken(length int) (string, error) {
 }
 
 func (tm *TokenManager) GetOrCreateServiceToken(serviceName string) (string, error) {
+	return tm.getOrCreateToken(context.Background(), ServiceTokenKind, serviceName)
+}
+
+func (tm *TokenManager) GetOrCreateUserToken(userName string) (string, error) {
+	return tm.getOrCreateToken(context.Background(), UserTokenKind, userName)
+}
+
+// GetOrCreateServiceTokenContext is GetOrCreateServiceToken with a context,
+// so callers can bound cancellation and, via WithoutCache, scope a cache
+// bypass to a single request.
+func (tm *TokenManager) GetOrCreateServiceTokenContext(ctx context.Context, serviceName string) (string, error) {
+	return tm.getOrCreateToken(ctx, ServiceTokenKind, serviceName)
+}
+
+// GetOrCreateUserTokenContext is GetOrCreateUserToken with a context, so
+// callers can bound cancellation and, via WithoutCache, scope a cache
+// bypass to a single request.
+func (tm *TokenManager) GetOrCreateUserTokenContext(ctx context.Context, userName string) (string, error) {
+	return tm.getOrCreateToken(ctx, UserTokenKind, userName)
+}
+
+func (tm *TokenManager) getOrCreateToken(ctx context.Context, kind TokenKind, name string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	key := cacheKey(kind, name)
+	useCache := tm.cache != nil
+
+	if useCache && !bypassCache(ctx) {
+		if value, ok := tm.cache.Get(key); ok {
+			return value, nil
+		}
+	}
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	var token string
+	tok, err := tm.jar.Get(kind, name)
+	if err == nil {
+		if useCache {
+			tm.cache.Set(key, tok.Value, 0)
+		}
+		return tok.Value, nil
+	}
+	if err != ErrTokenNotFound {
+		return "", err
+	}
 
-	err := tm.db.QueryRow("SELECT token FROM service_tokens WHERE service_name = $1", serviceName).Scan(&token)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			for {
-				tk, err := GenerateRandomToken(TokenLength)
-				if err != nil {
-					return "", err
-				}
+	c, hasCreator := tm.jar.(creator)
+
+	for {
+		tk, err := GenerateRandomToken(TokenLength)
+		if err != nil {
+			return "", err
+		}
+
+		newToken := Token{
+			Kind:      kind,
+			Name:      name,
+			Value:     tk,
+			CreatedAt: time.Now(),
+			ExpiresAt: tm.expiresAt(),
+		}
 
-				_, err = tm.db.Exec("INSERT INTO service_tokens (token, service_name) VALUES ($1, $2)", tk, serviceName)
+		if hasCreator {
+			created, err := c.Create(newToken)
+			if err != nil {
+				if isUniqueViolation(err) {
+					continue
+				}
+				return "", err
+			}
+			if !created {
+				// A concurrent getOrCreateToken for the same name won the
+				// race and created the row first; use what it persisted
+				// instead of silently overwriting it.
+				tok, err := tm.jar.Get(kind, name)
 				if err != nil {
-					if isUniqueViolation(err) {
-						continue
-					}
 					return "", err
 				}
-				token = tk
-				break
+				if useCache {
+					tm.cache.Set(key, tok.Value, 0)
+				}
+				return tok.Value, nil
+			}
+		} else if err := tm.jar.Put(newToken); err != nil {
+			if isUniqueViolation(err) {
+				continue
 			}
-		} else {
 			return "", err
 		}
+
+		if useCache {
+			tm.cache.Set(key, tk, 0)
+		}
+		return tk, nil
 	}
+}
 
-	return token, nil
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, ErrDuplicateToken) {
+		return true
+	}
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+	return pqErr.Code == "23505"
 }
 
-func (tm *TokenManager) GetOrCreateUserToken(userName string) (string, error) {
+// expiresAt returns the expires_at value to store for a newly issued token
+// under the manager's policy, or nil for a token that never expires.
+func (tm *TokenManager) expiresAt() *time.Time {
+	if tm.policy.TTL <= 0 {
+		return nil
+	}
+	t := time.Now().Add(tm.policy.TTL)
+	return &t
+}
+
+// VerifyKey parses a combined "lkp-<serviceToken>-<userToken>" key and
+// confirms that both halves name active, non-revoked, non-expired tokens. It
+// returns the service and user names the key was issued for.
+func (tm *TokenManager) VerifyKey(key string) (serviceName, userName string, err error) {
+	parts := strings.SplitN(key, "-", 3)
+	if len(parts) != 3 || parts[0] != Prefix {
+		return "", "", ErrMalformedKey
+	}
+	serviceToken, userToken := parts[1], parts[2]
+
+	serviceName, err = tm.verifyAndTouch(ServiceTokenKind, serviceToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	userName, err = tm.verifyAndTouch(UserTokenKind, userToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	return serviceName, userName, nil
+}
+
+// verifyAndTouch checks a single token value against the manager's policy
+// and records its use. Jars that implement verifier (PostgresTokenJar) look
+// the token up and record its use atomically under a row lock, and the
+// policy is then checked on the locked values after that commit, so there a
+// presentation the policy rejects is still counted. Other jars fall back to
+// the manager's mutex, which only protects a single process, and record
+// only accepted uses.
+func (tm *TokenManager) verifyAndTouch(kind TokenKind, value string) (string, error) {
+	if v, ok := tm.jar.(verifier); ok {
+		tok, err := v.VerifyAndTouch(kind, value)
+		if err != nil {
+			return "", err
+		}
+		return tm.checkPolicy(tok)
+	}
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	var token string
+	tokens, err := tm.jar.List(kind)
+	if err != nil {
+		return "", err
+	}
+	for _, tok := range tokens {
+		matched := tok
+		switch value {
+		case tok.Value:
+		case tok.PreviousValue:
+			if tok.PreviousValue == "" {
+				continue
+			}
+			// Within the grace period, a previous token behaves like the
+			// current one but expires on its own schedule. Once that grace
+			// period has elapsed the row simply doesn't match this value
+			// anymore - matching PostgresTokenJar.VerifyAndTouch, not
+			// ErrTokenExpired.
+			if tok.PreviousExpiresAt == nil || time.Now().After(*tok.PreviousExpiresAt) {
+				continue
+			}
+			matched.ExpiresAt = tok.PreviousExpiresAt
+		default:
+			continue
+		}
 
-	err := tm.db.QueryRow("SELECT token FROM user_tokens WHERE user_name = $1", userName).Scan(&token)
+		name, err := tm.checkPolicy(matched)
+		if err != nil {
+			return "", err
+		}
+		now := time.Now()
+		tok.LastUsedAt = &now
+		tok.UseCount++
+		if err := tm.jar.Put(tok); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	return "", ErrTokenNotFound
+}
+
+// checkPolicy validates a looked-up token against revocation/expiry/use-count
+// and returns its name, or the relevant error. tok.UseCount must be the
+// count *before* the in-flight verification - MaxUses caps how many times
+// VerifyKey accepts a token, so use number MaxUses is still accepted and
+// every later use is rejected once UseCount reaches MaxUses.
+func (tm *TokenManager) checkPolicy(tok Token) (string, error) {
+	if tok.RevokedAt != nil {
+		return "", ErrTokenRevoked
+	}
+	if tok.ExpiresAt != nil && time.Now().After(*tok.ExpiresAt) {
+		return "", ErrTokenExpired
+	}
+	if tm.policy.MaxUses > 0 && tok.UseCount >= tm.policy.MaxUses {
+		return "", ErrTokenExpired
+	}
+	return tok.Name, nil
+}
+
+// RevokeServiceToken marks a service token as revoked; future VerifyKey calls
+// against it return ErrTokenRevoked.
+func (tm *TokenManager) RevokeServiceToken(serviceName string) error {
+	return tm.revoke(ServiceTokenKind, serviceName)
+}
+
+// RevokeUserToken marks a user token as revoked; future VerifyKey calls
+// against it return ErrTokenRevoked.
+func (tm *TokenManager) RevokeUserToken(userName string) error {
+	return tm.revoke(UserTokenKind, userName)
+}
+
+func (tm *TokenManager) revoke(kind TokenKind, name string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tok, err := tm.jar.Get(kind, name)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			for {
-				tk, err := GenerateRandomToken(TokenLength)
-				if err != nil {
-					return "", err
-				}
+		return err
+	}
+	if tok.RevokedAt == nil {
+		now := time.Now()
+		tok.RevokedAt = &now
+		if err := tm.jar.Put(tok); err != nil {
+			return err
+		}
+	}
 
-				_, err = tm.db.Exec("INSERT INTO user_tokens (token, user_name) VALUES ($1, $2)", tk, userName)
-				if err != nil {
-					if isUniqueViolation(err) {
-						continue
-					}
-					return "", err
-				}
-				token = tk
-				break
+	if tm.cache != nil {
+		tm.cache.Delete(cacheKey(kind, name))
+	}
+	return nil
+}
+
+// RotateToken generates a new token for the given kind and name, keeping the
+// old token valid for tm.policy.GracePeriod so in-flight callers holding it
+// don't fail.
+func (tm *TokenManager) RotateToken(kind TokenKind, name string) (newToken string, err error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	old, err := tm.jar.Get(kind, name)
+	if err != nil && err != ErrTokenNotFound {
+		return "", err
+	}
+
+	var previousValue string
+	var previousExpiresAt *time.Time
+	if err == nil && old.RevokedAt == nil {
+		grace := time.Now().Add(tm.policy.GracePeriod)
+		previousValue = old.Value
+		previousExpiresAt = &grace
+	}
+
+	for {
+		tk, err := GenerateRandomToken(TokenLength)
+		if err != nil {
+			return "", err
+		}
+
+		err = tm.jar.Put(Token{
+			Kind:              kind,
+			Name:              name,
+			Value:             tk,
+			CreatedAt:         time.Now(),
+			ExpiresAt:         tm.expiresAt(),
+			PreviousValue:     previousValue,
+			PreviousExpiresAt: previousExpiresAt,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				continue
 			}
-		} else {
 			return "", err
 		}
+
+		if tm.cache != nil {
+			tm.cache.Set(cacheKey(kind, name), tk, 0)
+		}
+		return tk, nil
 	}
+}
 
-	return token, nil
+// Client is a reusable, context-aware handle onto a LoyalKeyPatron deployment.
+// Unlike Crypto, it opens its database pool once (via NewClient) and returns
+// errors instead of exiting the process, so it's usable as a library inside
+// a long-running service.
+type Client struct {
+	db *sql.DB
+	tm *TokenManager
 }
 
-func isUniqueViolation(err error) bool {
-	pqErr, ok := err.(*pq.Error)
-	if !ok {
-		return false
+// NewClient opens a connection pool per config and verifies it with a ping
+// before returning. The caller owns the returned Client and must call Close
+// when done with it.
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	db, err := sql.Open("postgres", dsn(config))
+	if err != nil {
+		return nil, fmt.Errorf("cryptography: opening database: %w", err)
 	}
-	return pqErr.Code == "23505"
+
+	if config.DBMaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.DBMaxOpenConns)
+	}
+	if config.DBMaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.DBMaxIdleConns)
+	}
+	if config.DBConnMaxLifetimeSeconds > 0 {
+		db.SetConnMaxLifetime(time.Duration(config.DBConnMaxLifetimeSeconds) * time.Second)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cryptography: pinging database: %w", err)
+	}
+
+	return &Client{db: db, tm: NewTokenManager(NewPostgresTokenJar(db))}, nil
 }
 
-func Crypto(serviceName string, userName string) string {
+// dsn builds a postgres connection string from config, defaulting
+// DBSSLMode to "disable" to match this package's historical behavior.
+func dsn(config Config) string {
+	sslMode := config.DBSSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName, sslMode,
+	)
+}
+
+// Close releases the Client's database pool.
+func (c *Client) Close() error {
+	return c.db.Close()
+}
+
+// TokenManager returns the TokenManager backing this Client, for callers
+// that need VerifyKey/RevokeServiceToken/RevokeUserToken/RotateToken rather
+// than just issuance.
+func (c *Client) TokenManager() *TokenManager {
+	return c.tm
+}
+
+// IssueKey returns the combined "lkp-<serviceToken>-<userToken>" key for
+// serviceName and userName, creating either token if it doesn't exist yet.
+func (c *Client) IssueKey(ctx context.Context, serviceName, userName string) (string, error) {
+	serviceToken, err := c.tm.GetOrCreateServiceTokenContext(ctx, serviceName)
+	if err != nil {
+		return "", fmt.Errorf("cryptography: issuing service token: %w", err)
+	}
+
+	userToken, err := c.tm.GetOrCreateUserTokenContext(ctx, userName)
+	if err != nil {
+		return "", fmt.Errorf("cryptography: issuing user token: %w", err)
+	}
 
+	return fmt.Sprintf("%s-%s-%s", Prefix, serviceToken, userToken), nil
+}
+
+// IssueSealedKey is like IssueKey, but returns an AES-GCM sealed key (see
+// EncryptedTokenCodec) instead of the raw "lkp-<service>-<user>" format.
+// TokenManager still records the issuance server-side; the sealed key
+// additionally lets downstream services verify it locally via
+// EncryptedTokenCodec.Open, with no DB round-trip.
+func (c *Client) IssueSealedKey(ctx context.Context, config Config, serviceName, userName string) (string, error) {
+	if _, err := c.tm.GetOrCreateServiceTokenContext(ctx, serviceName); err != nil {
+		return "", fmt.Errorf("cryptography: issuing service token: %w", err)
+	}
+	if _, err := c.tm.GetOrCreateUserTokenContext(ctx, userName); err != nil {
+		return "", fmt.Errorf("cryptography: issuing user token: %w", err)
+	}
+
+	keys, err := LoadTokenKeys(&config)
+	if err != nil {
+		return "", err
+	}
+	codec, err := NewEncryptedTokenCodec(keys...)
+	if err != nil {
+		return "", err
+	}
+
+	issuedAt := time.Now()
+	payload := Payload{ServiceName: serviceName, UserName: userName, IssuedAt: issuedAt}
+	if c.tm.policy.TTL > 0 {
+		payload.ExpiresAt = issuedAt.Add(c.tm.policy.TTL)
+	}
+
+	return codec.Seal(payload)
+}
+
+// Crypto issues a combined "lkp-<serviceToken>-<userToken>" key for
+// serviceName and userName, reading configs/database_config.json from the
+// current working directory.
+//
+// Deprecated: Crypto calls log.Fatalf on any error and opens a fresh
+// database pool on every call, which makes it unusable as a library
+// dependency. Use NewClient and Client.IssueKey instead; this wrapper exists
+// only for callers that haven't migrated yet.
+func Crypto(serviceName string, userName string) string {
 	config, err := LoadConfig("configs/database_config.json")
 	if err != nil {
 		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
 	}
 
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName)
-
-	db, err := sql.Open("postgres", connStr)
+	client, err := NewClient(context.Background(), *config)
 	if err != nil {
 		log.Fatalf("Ошибка подключения к базе данных: %v", err)
 	}
-	defer db.Close()
+	defer client.Close()
 
-	err = db.Ping()
+	key, err := client.IssueKey(context.Background(), serviceName, userName)
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к базе данных: %v", err)
+		log.Fatalf("Ошибка генерации ключа: %v", err)
 	}
 
-	tokenManager := NewTokenManager(db)
+	return key
+}
 
-	serviceToken, err := tokenManager.GetOrCreateServiceToken(serviceName)
+// CryptoSealed is like Crypto, but returns an AES-GCM sealed key via
+// Client.IssueSealedKey.
+//
+// Deprecated: use NewClient and Client.IssueSealedKey instead.
+func CryptoSealed(serviceName, userName string) (string, error) {
+	config, err := LoadConfig("configs/database_config.json")
 	if err != nil {
-		log.Fatalf("Ошибка генерации токена сервиса: %v", err)
+		return "", fmt.Errorf("loading config: %w", err)
 	}
 
-	userToken, err := tokenManager.GetOrCreateUserToken(userName)
+	client, err := NewClient(context.Background(), *config)
 	if err != nil {
-		log.Fatalf("Ошибка генерации токена пользователя: %v", err)
+		return "", err
 	}
+	defer client.Close()
 
-	key := fmt.Sprintf("%s-%s-%s", Prefix, serviceToken, userToken)
-	return key
+	return client.IssueSealedKey(context.Background(), *config, serviceName, userName)
 }
@@ -0,0 +1,153 @@
+package cryptography
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileTokenJar is a TokenJar backed by a single JSON file, for embedded or
+// CLI usage where running Postgres is overkill. Writes are batched: Put and
+// Delete only mark the jar dirty, and a background goroutine flushes to
+// disk every WriteInterval. Call Flush to force a write (e.g. before
+// shutdown).
+type FileTokenJar struct {
+	path          string
+	writeInterval time.Duration
+
+	mu     sync.Mutex
+	tokens map[TokenKind]map[string]Token
+	dirty  bool
+	stop   chan struct{}
+}
+
+// NewFileTokenJar loads path if it exists (an empty jar otherwise) and, if
+// writeInterval > 0, starts a background goroutine batching writes on that
+// interval. Callers that pass writeInterval <= 0 get synchronous writes on
+// every Put/Delete instead.
+func NewFileTokenJar(path string, writeInterval time.Duration) (*FileTokenJar, error) {
+	j := &FileTokenJar{
+		path:          path,
+		writeInterval: writeInterval,
+		tokens: map[TokenKind]map[string]Token{
+			ServiceTokenKind: {},
+			UserTokenKind:    {},
+		},
+		stop: make(chan struct{}),
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &j.tokens); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if writeInterval > 0 {
+		go j.flushLoop()
+	}
+
+	return j, nil
+}
+
+func (j *FileTokenJar) flushLoop() {
+	ticker := time.NewTicker(j.writeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = j.Flush()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background flush goroutine and writes out any pending
+// changes.
+func (j *FileTokenJar) Close() error {
+	close(j.stop)
+	return j.Flush()
+}
+
+func (j *FileTokenJar) Get(kind TokenKind, name string) (Token, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tok, ok := j.tokens[kind][name]
+	if !ok {
+		return Token{}, ErrTokenNotFound
+	}
+	return tok, nil
+}
+
+func (j *FileTokenJar) Put(token Token) error {
+	j.mu.Lock()
+	for name, existing := range j.tokens[token.Kind] {
+		if name == token.Name {
+			continue
+		}
+		if existing.Value == token.Value || existing.PreviousValue == token.Value {
+			j.mu.Unlock()
+			return ErrDuplicateToken
+		}
+	}
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	j.tokens[token.Kind][token.Name] = token
+	j.dirty = true
+	synchronous := j.writeInterval <= 0
+	j.mu.Unlock()
+
+	if synchronous {
+		return j.Flush()
+	}
+	return nil
+}
+
+func (j *FileTokenJar) Delete(kind TokenKind, name string) error {
+	j.mu.Lock()
+	delete(j.tokens[kind], name)
+	j.dirty = true
+	synchronous := j.writeInterval <= 0
+	j.mu.Unlock()
+
+	if synchronous {
+		return j.Flush()
+	}
+	return nil
+}
+
+func (j *FileTokenJar) List(kind TokenKind) ([]Token, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tokens := make([]Token, 0, len(j.tokens[kind]))
+	for _, tok := range j.tokens[kind] {
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// Flush writes the jar to disk if there are unwritten changes.
+func (j *FileTokenJar) Flush() error {
+	j.mu.Lock()
+	if !j.dirty {
+		j.mu.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(j.tokens, "", "  ")
+	if err != nil {
+		j.mu.Unlock()
+		return err
+	}
+	j.dirty = false
+	j.mu.Unlock()
+
+	return os.WriteFile(j.path, data, 0o600)
+}
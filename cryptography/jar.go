@@ -0,0 +1,91 @@
+package cryptography
+
+import (
+	"errors"
+	"time"
+)
+
+// TokenKind distinguishes the two token tables LoyalKeyPatron issues keys
+// from.
+type TokenKind string
+
+const (
+	ServiceTokenKind TokenKind = "service"
+	UserTokenKind    TokenKind = "user"
+)
+
+// Token is a single issued token as stored by a TokenJar, independent of
+// whatever backend holds it.
+type Token struct {
+	Kind       TokenKind
+	Name       string
+	Value      string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+
+	// PreviousValue and PreviousExpiresAt hold the token RotateToken
+	// replaced, if any, so it keeps validating until PreviousExpiresAt -
+	// covering callers that cached the old key across the rotation.
+	PreviousValue     string
+	PreviousExpiresAt *time.Time
+
+	// UseCount is how many times VerifyKey has accepted this token, for
+	// TokenPolicy.MaxUses enforcement. It reflects the count *before* the
+	// in-flight verification, which is what TokenManager checks against the
+	// policy before accepting and incrementing it. PostgresTokenJar
+	// increments it before the policy check, so there it also counts
+	// presentations the policy rejected.
+	UseCount int
+}
+
+// TokenJar is the persistence abstraction TokenManager depends on. Swapping
+// the jar lets TokenManager run against Postgres (PostgresTokenJar), in
+// tests (MemoryTokenJar), or embedded/CLI usage with no database at all
+// (FileTokenJar).
+type TokenJar interface {
+	// Get returns the token stored for (kind, name), or ErrTokenNotFound.
+	Get(kind TokenKind, name string) (Token, error)
+	// Put creates or overwrites the token for (kind, token.Name). It must
+	// reject the write with ErrDuplicateToken if token.Value already names
+	// a different (kind, name) - VerifyKey's lookup-by-value depends on
+	// values being unique within a kind.
+	Put(token Token) error
+	// Delete removes the token stored for (kind, name), if any.
+	Delete(kind TokenKind, name string) error
+	// List returns every token of the given kind.
+	List(kind TokenKind) ([]Token, error)
+	// Flush persists any buffered writes. Jars that write synchronously
+	// (e.g. PostgresTokenJar) treat this as a no-op.
+	Flush() error
+}
+
+// verifier is implemented by jars that can check-and-touch a token by its
+// value atomically, guarding against a concurrent revoke or rotation racing
+// a verification even across processes. Jars without native locking
+// (MemoryTokenJar, FileTokenJar) don't implement it, so TokenManager falls
+// back to its own mutex, which only protects a single process - acceptable
+// for the embedded/CLI use case those jars target.
+type verifier interface {
+	VerifyAndTouch(kind TokenKind, value string) (Token, error)
+}
+
+// creator is implemented by jars that can create a token row atomically,
+// without clobbering a row a concurrent process already created for the
+// same (kind, name) - something Put's unconditional overwrite can't
+// guarantee across processes. getOrCreateToken prefers it when available;
+// jars without cross-process concurrency concerns (MemoryTokenJar,
+// FileTokenJar) don't implement it, since TokenManager's own mutex already
+// serializes their single process.
+type creator interface {
+	// Create inserts token if no row exists yet for (kind, token.Name) and
+	// reports whether it did. A false, nil return means a concurrent Create
+	// won the race; the caller should Get the row it created instead.
+	Create(token Token) (created bool, err error)
+}
+
+// ErrDuplicateToken is returned by TokenJar.Put when token.Value already
+// names a different (kind, name) pair. TokenManager treats it the same as a
+// Postgres unique-violation: retry with a freshly generated value.
+var ErrDuplicateToken = errors.New("cryptography: token value already in use")
@@ -0,0 +1,78 @@
+package cryptography
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenJarPutRejectsDuplicateValue(t *testing.T) {
+	jar := NewMemoryTokenJar()
+
+	if err := jar.Put(Token{Kind: ServiceTokenKind, Name: "billing", Value: "sametoken"}); err != nil {
+		t.Fatalf("Put billing: %v", err)
+	}
+	err := jar.Put(Token{Kind: ServiceTokenKind, Name: "payments", Value: "sametoken"})
+	if !errors.Is(err, ErrDuplicateToken) {
+		t.Fatalf("Put payments with colliding value: got %v, want ErrDuplicateToken", err)
+	}
+}
+
+func TestMemoryTokenJarPutAllowsOverwritingSameName(t *testing.T) {
+	jar := NewMemoryTokenJar()
+
+	if err := jar.Put(Token{Kind: ServiceTokenKind, Name: "billing", Value: "first"}); err != nil {
+		t.Fatalf("Put first: %v", err)
+	}
+	if err := jar.Put(Token{Kind: ServiceTokenKind, Name: "billing", Value: "second"}); err != nil {
+		t.Fatalf("Put second: %v", err)
+	}
+
+	tok, err := jar.Get(ServiceTokenKind, "billing")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if tok.Value != "second" {
+		t.Fatalf("Value = %q, want %q", tok.Value, "second")
+	}
+}
+
+func TestFileTokenJarPutRejectsDuplicateValue(t *testing.T) {
+	jar, err := NewFileTokenJar(filepath.Join(t.TempDir(), "tokens.json"), 0)
+	if err != nil {
+		t.Fatalf("NewFileTokenJar: %v", err)
+	}
+
+	if err := jar.Put(Token{Kind: ServiceTokenKind, Name: "billing", Value: "sametoken"}); err != nil {
+		t.Fatalf("Put billing: %v", err)
+	}
+	err = jar.Put(Token{Kind: ServiceTokenKind, Name: "payments", Value: "sametoken"})
+	if !errors.Is(err, ErrDuplicateToken) {
+		t.Fatalf("Put payments with colliding value: got %v, want ErrDuplicateToken", err)
+	}
+}
+
+func TestFileTokenJarPersistsAcrossReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	jar, err := NewFileTokenJar(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileTokenJar: %v", err)
+	}
+	if err := jar.Put(Token{Kind: UserTokenKind, Name: "alice", Value: "abc123", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reloaded, err := NewFileTokenJar(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileTokenJar (reload): %v", err)
+	}
+	tok, err := reloaded.Get(UserTokenKind, "alice")
+	if err != nil {
+		t.Fatalf("Get after reload: %v", err)
+	}
+	if tok.Value != "abc123" {
+		t.Fatalf("Value = %q, want %q", tok.Value, "abc123")
+	}
+}
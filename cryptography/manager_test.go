@@ -0,0 +1,119 @@
+package cryptography
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyKeyRoundTrip(t *testing.T) {
+	tm := NewTokenManager(NewMemoryTokenJar())
+
+	serviceToken, err := tm.GetOrCreateServiceToken("billing")
+	if err != nil {
+		t.Fatalf("GetOrCreateServiceToken: %v", err)
+	}
+	userToken, err := tm.GetOrCreateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUserToken: %v", err)
+	}
+
+	key := Prefix + "-" + serviceToken + "-" + userToken
+	serviceName, userName, err := tm.VerifyKey(key)
+	if err != nil {
+		t.Fatalf("VerifyKey: %v", err)
+	}
+	if serviceName != "billing" || userName != "alice" {
+		t.Fatalf("got (%q, %q), want (%q, %q)", serviceName, userName, "billing", "alice")
+	}
+}
+
+func TestVerifyKeyRotationGracePeriod(t *testing.T) {
+	tm := NewTokenManagerWithPolicy(NewMemoryTokenJar(), TokenPolicy{GracePeriod: time.Hour})
+
+	serviceToken, err := tm.GetOrCreateServiceToken("billing")
+	if err != nil {
+		t.Fatalf("GetOrCreateServiceToken: %v", err)
+	}
+	userToken, err := tm.GetOrCreateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUserToken: %v", err)
+	}
+
+	if _, err := tm.RotateToken(ServiceTokenKind, "billing"); err != nil {
+		t.Fatalf("RotateToken: %v", err)
+	}
+
+	key := Prefix + "-" + serviceToken + "-" + userToken
+	if _, _, err := tm.VerifyKey(key); err != nil {
+		t.Fatalf("VerifyKey with rotated-out service token should still pass within grace period: %v", err)
+	}
+}
+
+func TestVerifyKeyRotationExpiredGracePeriod(t *testing.T) {
+	tm := NewTokenManagerWithPolicy(NewMemoryTokenJar(), TokenPolicy{GracePeriod: -time.Hour})
+
+	serviceToken, err := tm.GetOrCreateServiceToken("billing")
+	if err != nil {
+		t.Fatalf("GetOrCreateServiceToken: %v", err)
+	}
+	userToken, err := tm.GetOrCreateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUserToken: %v", err)
+	}
+
+	if _, err := tm.RotateToken(ServiceTokenKind, "billing"); err != nil {
+		t.Fatalf("RotateToken: %v", err)
+	}
+
+	key := Prefix + "-" + serviceToken + "-" + userToken
+	if _, _, err := tm.VerifyKey(key); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("VerifyKey with grace period already elapsed: got %v, want ErrTokenNotFound", err)
+	}
+}
+
+func TestVerifyKeyRevoked(t *testing.T) {
+	tm := NewTokenManager(NewMemoryTokenJar())
+
+	serviceToken, err := tm.GetOrCreateServiceToken("billing")
+	if err != nil {
+		t.Fatalf("GetOrCreateServiceToken: %v", err)
+	}
+	userToken, err := tm.GetOrCreateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUserToken: %v", err)
+	}
+
+	if err := tm.RevokeServiceToken("billing"); err != nil {
+		t.Fatalf("RevokeServiceToken: %v", err)
+	}
+
+	key := Prefix + "-" + serviceToken + "-" + userToken
+	if _, _, err := tm.VerifyKey(key); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("VerifyKey after revoke: got %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestVerifyKeyMaxUses(t *testing.T) {
+	tm := NewTokenManagerWithPolicy(NewMemoryTokenJar(), TokenPolicy{MaxUses: 2})
+
+	serviceToken, err := tm.GetOrCreateServiceToken("billing")
+	if err != nil {
+		t.Fatalf("GetOrCreateServiceToken: %v", err)
+	}
+	userToken, err := tm.GetOrCreateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUserToken: %v", err)
+	}
+
+	key := Prefix + "-" + serviceToken + "-" + userToken
+	for i := 0; i < 2; i++ {
+		if _, _, err := tm.VerifyKey(key); err != nil {
+			t.Fatalf("VerifyKey use %d: %v", i+1, err)
+		}
+	}
+
+	if _, _, err := tm.VerifyKey(key); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("VerifyKey past MaxUses: got %v, want ErrTokenExpired", err)
+	}
+}
@@ -0,0 +1,78 @@
+package cryptography
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryTokenJar is a TokenJar backed by an in-process map, for use in tests
+// and anywhere a database is unavailable or undesired.
+type MemoryTokenJar struct {
+	mu     sync.Mutex
+	tokens map[TokenKind]map[string]Token
+}
+
+// NewMemoryTokenJar returns an empty MemoryTokenJar.
+func NewMemoryTokenJar() *MemoryTokenJar {
+	return &MemoryTokenJar{
+		tokens: map[TokenKind]map[string]Token{
+			ServiceTokenKind: {},
+			UserTokenKind:    {},
+		},
+	}
+}
+
+func (j *MemoryTokenJar) Get(kind TokenKind, name string) (Token, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tok, ok := j.tokens[kind][name]
+	if !ok {
+		return Token{}, ErrTokenNotFound
+	}
+	return tok, nil
+}
+
+func (j *MemoryTokenJar) Put(token Token) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for name, existing := range j.tokens[token.Kind] {
+		if name == token.Name {
+			continue
+		}
+		if existing.Value == token.Value || existing.PreviousValue == token.Value {
+			return ErrDuplicateToken
+		}
+	}
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	j.tokens[token.Kind][token.Name] = token
+	return nil
+}
+
+func (j *MemoryTokenJar) Delete(kind TokenKind, name string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.tokens[kind], name)
+	return nil
+}
+
+func (j *MemoryTokenJar) List(kind TokenKind) ([]Token, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tokens := make([]Token, 0, len(j.tokens[kind]))
+	for _, tok := range j.tokens[kind] {
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// Flush is a no-op: MemoryTokenJar never buffers writes.
+func (j *MemoryTokenJar) Flush() error {
+	return nil
+}
@@ -0,0 +1,260 @@
+package cryptography
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresTokenJar is the original TokenJar backend: one row per service or
+// user name in the service_tokens/user_tokens tables.
+type PostgresTokenJar struct {
+	db *sql.DB
+}
+
+// NewPostgresTokenJar wraps an already-open *sql.DB. The pool is owned by
+// the caller; PostgresTokenJar never closes it.
+func NewPostgresTokenJar(db *sql.DB) *PostgresTokenJar {
+	return &PostgresTokenJar{db: db}
+}
+
+func (j *PostgresTokenJar) tableFor(kind TokenKind) (table, nameColumn string, err error) {
+	switch kind {
+	case ServiceTokenKind:
+		return "service_tokens", "service_name", nil
+	case UserTokenKind:
+		return "user_tokens", "user_name", nil
+	default:
+		return "", "", fmt.Errorf("cryptography: unknown token kind %q", kind)
+	}
+}
+
+func (j *PostgresTokenJar) Get(kind TokenKind, name string) (Token, error) {
+	table, nameColumn, err := j.tableFor(kind)
+	if err != nil {
+		return Token{}, err
+	}
+
+	query := fmt.Sprintf(
+		"SELECT token, created_at, expires_at, revoked_at, last_used_at, previous_token, previous_expires_at, use_count FROM %s WHERE %s = $1",
+		table, nameColumn,
+	)
+
+	tok := Token{Kind: kind, Name: name}
+	var expiresAt, revokedAt, lastUsedAt, previousExpiresAt sql.NullTime
+	var previousToken sql.NullString
+	err = j.db.QueryRow(query, name).Scan(
+		&tok.Value, &tok.CreatedAt, &expiresAt, &revokedAt, &lastUsedAt, &previousToken, &previousExpiresAt, &tok.UseCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Token{}, ErrTokenNotFound
+		}
+		return Token{}, err
+	}
+	tok.ExpiresAt = nullTimePtr(expiresAt)
+	tok.RevokedAt = nullTimePtr(revokedAt)
+	tok.LastUsedAt = nullTimePtr(lastUsedAt)
+	tok.PreviousValue = previousToken.String
+	tok.PreviousExpiresAt = nullTimePtr(previousExpiresAt)
+
+	return tok, nil
+}
+
+// Put unconditionally creates or overwrites the row for (kind, token.Name) -
+// correct for the explicit Revoke*/RotateToken call sites, which already
+// hold the row (or are deliberately replacing it), but not for
+// getOrCreateToken's create-if-absent case; use Create there instead.
+func (j *PostgresTokenJar) Put(token Token) error {
+	table, nameColumn, err := j.tableFor(token.Kind)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (token, %s, created_at, expires_at, revoked_at, last_used_at, previous_token, previous_expires_at, use_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (%s) DO UPDATE SET
+			token = EXCLUDED.token,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at,
+			revoked_at = EXCLUDED.revoked_at,
+			last_used_at = EXCLUDED.last_used_at,
+			previous_token = EXCLUDED.previous_token,
+			previous_expires_at = EXCLUDED.previous_expires_at,
+			use_count = EXCLUDED.use_count
+	`, table, nameColumn, nameColumn)
+
+	_, err = j.db.Exec(query, j.putArgs(token)...)
+	return err
+}
+
+// Create inserts token only if no row exists yet for (kind, token.Name),
+// via ON CONFLICT (%s) DO NOTHING - unlike Put, a racing process that creates
+// the row first is left untouched instead of silently clobbered. See the
+// creator interface.
+func (j *PostgresTokenJar) Create(token Token) (bool, error) {
+	table, nameColumn, err := j.tableFor(token.Kind)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (token, %s, created_at, expires_at, revoked_at, last_used_at, previous_token, previous_expires_at, use_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (%s) DO NOTHING
+	`, table, nameColumn, nameColumn)
+
+	res, err := j.db.Exec(query, j.putArgs(token)...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// putArgs builds the positional arguments shared by Put and Create's INSERT.
+func (j *PostgresTokenJar) putArgs(token Token) []any {
+	createdAt := token.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	var previousToken *string
+	if token.PreviousValue != "" {
+		previousToken = &token.PreviousValue
+	}
+
+	return []any{
+		token.Value, token.Name, createdAt, token.ExpiresAt, token.RevokedAt, token.LastUsedAt,
+		previousToken, token.PreviousExpiresAt, token.UseCount,
+	}
+}
+
+func (j *PostgresTokenJar) Delete(kind TokenKind, name string) error {
+	table, nameColumn, err := j.tableFor(kind)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = $1", table, nameColumn), name)
+	return err
+}
+
+func (j *PostgresTokenJar) List(kind TokenKind) ([]Token, error) {
+	table, nameColumn, err := j.tableFor(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := j.db.Query(fmt.Sprintf(
+		"SELECT %s, token, created_at, expires_at, revoked_at, last_used_at, previous_token, previous_expires_at, use_count FROM %s",
+		nameColumn, table,
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []Token
+	for rows.Next() {
+		tok := Token{Kind: kind}
+		var expiresAt, revokedAt, lastUsedAt, previousExpiresAt sql.NullTime
+		var previousToken sql.NullString
+		if err := rows.Scan(
+			&tok.Name, &tok.Value, &tok.CreatedAt, &expiresAt, &revokedAt, &lastUsedAt, &previousToken, &previousExpiresAt, &tok.UseCount,
+		); err != nil {
+			return nil, err
+		}
+		tok.ExpiresAt = nullTimePtr(expiresAt)
+		tok.RevokedAt = nullTimePtr(revokedAt)
+		tok.LastUsedAt = nullTimePtr(lastUsedAt)
+		tok.PreviousValue = previousToken.String
+		tok.PreviousExpiresAt = nullTimePtr(previousExpiresAt)
+		tokens = append(tokens, tok)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Flush is a no-op: every Put/Delete above already committed to Postgres.
+func (j *PostgresTokenJar) Flush() error {
+	return nil
+}
+
+// VerifyAndTouch locks the matching row with SELECT ... FOR UPDATE inside a
+// transaction, so a concurrent RevokeServiceToken/RevokeUserToken/
+// RotateToken on the same row cannot race a verification in progress, even
+// across processes - something an in-process sync.Mutex cannot guarantee.
+// value may match either the row's current token or, during a rotation's
+// grace period, its previous_token.
+func (j *PostgresTokenJar) VerifyAndTouch(kind TokenKind, value string) (Token, error) {
+	table, nameColumn, err := j.tableFor(kind)
+	if err != nil {
+		return Token{}, err
+	}
+
+	tx, err := j.db.Begin()
+	if err != nil {
+		return Token{}, err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(
+		"SELECT %s, created_at, expires_at, revoked_at, previous_token, previous_expires_at, use_count FROM %s WHERE token = $1 OR previous_token = $1 FOR UPDATE",
+		nameColumn, table,
+	)
+
+	var name string
+	var createdAt time.Time
+	var expiresAt, revokedAt, previousExpiresAt sql.NullTime
+	var previousToken sql.NullString
+	var useCount int
+	err = tx.QueryRow(query, value).Scan(&name, &createdAt, &expiresAt, &revokedAt, &previousToken, &previousExpiresAt, &useCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Token{}, ErrTokenNotFound
+		}
+		return Token{}, err
+	}
+
+	// A match on previous_token is only live within its grace period; after
+	// that it behaves as if the row was never found for this value.
+	if previousToken.Valid && previousToken.String == value {
+		if !previousExpiresAt.Valid || time.Now().After(previousExpiresAt.Time) {
+			return Token{}, ErrTokenNotFound
+		}
+		expiresAt = previousExpiresAt
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET last_used_at = now(), use_count = use_count + 1 WHERE token = $1 OR previous_token = $1", table), value); err != nil {
+		return Token{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Token{}, err
+	}
+
+	now := time.Now()
+	return Token{
+		Kind:       kind,
+		Name:       name,
+		Value:      value,
+		CreatedAt:  createdAt,
+		ExpiresAt:  nullTimePtr(expiresAt),
+		RevokedAt:  nullTimePtr(revokedAt),
+		LastUsedAt: &now,
+		UseCount:   useCount,
+	}, nil
+}
+
+func nullTimePtr(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	t := nt.Time
+	return &t
+}
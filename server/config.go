@@ -0,0 +1,51 @@
+// Package server exposes TokenManager over HTTP/JSON, so other services in
+// a microservice deployment can consume LoyalKeyPatron over the network
+// instead of importing the package and each spinning up their own DB pool.
+package server
+
+import (
+	"io"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/Danikalyk/LoyalKeyPatron/cryptography"
+)
+
+// Config is the server's TOML configuration.
+type Config struct {
+	// Addr is the address ListenAndServe binds, e.g. ":8080".
+	Addr string `toml:"addr"`
+
+	DBHost     string `toml:"db_host"`
+	DBPort     int    `toml:"db_port"`
+	DBUser     string `toml:"db_user"`
+	DBPassword string `toml:"db_password"`
+	DBName     string `toml:"db_name"`
+
+	// TokenKey is the base64 AES-256 key EncryptedTokenCodec seals keys
+	// under. Optional - if empty, LoadTokenKeys falls back to
+	// cryptography.TokenKeyEnvVar.
+	TokenKey string `toml:"token_key"`
+}
+
+// ConfigFromReader parses a TOML document into a Config.
+func ConfigFromReader(r io.Reader) (*Config, error) {
+	var cfg Config
+	if _, err := toml.NewDecoder(r).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// CryptographyConfig adapts Config to cryptography.Config, for
+// cryptography.LoadTokenKeys and opening the database connection.
+func (c *Config) CryptographyConfig() *cryptography.Config {
+	return &cryptography.Config{
+		DBHost:     c.DBHost,
+		DBPort:     c.DBPort,
+		DBUser:     c.DBUser,
+		DBPassword: c.DBPassword,
+		DBName:     c.DBName,
+		TokenKey:   c.TokenKey,
+	}
+}
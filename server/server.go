@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Danikalyk/LoyalKeyPatron/cryptography"
+)
+
+// Server wraps a cryptography.TokenManager in an HTTP/JSON API.
+type Server struct {
+	tm     *cryptography.TokenManager
+	logger *slog.Logger
+	http   *http.Server
+}
+
+// New builds a Server listening on cfg.Addr and issuing/verifying tokens
+// through tm. logger defaults to slog.Default() when nil.
+func New(cfg *Config, tm *cryptography.TokenManager, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Server{tm: tm, logger: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/tokens/service", s.postOnly(s.handleIssueServiceToken))
+	mux.HandleFunc("/v1/tokens/user", s.postOnly(s.handleIssueUserToken))
+	mux.HandleFunc("/v1/keys", s.postOnly(s.handleIssueKey))
+	mux.HandleFunc("/v1/keys/verify", s.postOnly(s.handleVerifyKey))
+
+	s.http = &http.Server{Addr: cfg.Addr, Handler: mux}
+	return s
+}
+
+// postOnly wraps a handler to reject anything but POST, since ServeMux here
+// targets Go 1.21 and can't express that via its pattern syntax.
+func (s *Server) postOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// ListenAndServe starts serving and blocks until the server is shut down or
+// fails to start. It always returns a non-nil error (http.ErrServerClosed on
+// a clean Shutdown).
+func (s *Server) ListenAndServe() error {
+	s.logger.Info("starting server", "addr", s.http.Addr)
+	return s.http.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests to
+// finish or ctx to be done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Info("shutting down server")
+	return s.http.Shutdown(ctx)
+}
+
+type serviceTokenRequest struct {
+	ServiceName string `json:"service_name"`
+}
+
+type userTokenRequest struct {
+	UserName string `json:"user_name"`
+}
+
+type keyRequest struct {
+	ServiceName string `json:"service_name"`
+	UserName    string `json:"user_name"`
+}
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+type keyResponse struct {
+	Key string `json:"key"`
+}
+
+type verifyKeyRequest struct {
+	Key string `json:"key"`
+}
+
+type verifyKeyResponse struct {
+	ServiceName string `json:"service_name"`
+	UserName    string `json:"user_name"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleIssueServiceToken(w http.ResponseWriter, r *http.Request) {
+	var req serviceTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ServiceName == "" {
+		writeError(w, http.StatusBadRequest, errors.New("service_name is required"))
+		return
+	}
+
+	token, err := s.tm.GetOrCreateServiceTokenContext(r.Context(), req.ServiceName)
+	if err != nil {
+		s.writeTokenError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{Token: token})
+}
+
+func (s *Server) handleIssueUserToken(w http.ResponseWriter, r *http.Request) {
+	var req userTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserName == "" {
+		writeError(w, http.StatusBadRequest, errors.New("user_name is required"))
+		return
+	}
+
+	token, err := s.tm.GetOrCreateUserTokenContext(r.Context(), req.UserName)
+	if err != nil {
+		s.writeTokenError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{Token: token})
+}
+
+func (s *Server) handleIssueKey(w http.ResponseWriter, r *http.Request) {
+	var req keyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ServiceName == "" || req.UserName == "" {
+		writeError(w, http.StatusBadRequest, errors.New("service_name and user_name are required"))
+		return
+	}
+
+	serviceToken, err := s.tm.GetOrCreateServiceTokenContext(r.Context(), req.ServiceName)
+	if err != nil {
+		s.writeTokenError(w, err)
+		return
+	}
+	userToken, err := s.tm.GetOrCreateUserTokenContext(r.Context(), req.UserName)
+	if err != nil {
+		s.writeTokenError(w, err)
+		return
+	}
+
+	key := fmt.Sprintf("%s-%s-%s", cryptography.Prefix, serviceToken, userToken)
+	writeJSON(w, http.StatusOK, keyResponse{Key: key})
+}
+
+func (s *Server) handleVerifyKey(w http.ResponseWriter, r *http.Request) {
+	var req verifyKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+
+	serviceName, userName, err := s.tm.VerifyKey(req.Key)
+	if err != nil {
+		s.writeTokenError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verifyKeyResponse{ServiceName: serviceName, UserName: userName})
+}
+
+// writeTokenError maps a TokenManager error to the appropriate HTTP status.
+func (s *Server) writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, cryptography.ErrMalformedKey):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, cryptography.ErrTokenNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, cryptography.ErrTokenRevoked):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, cryptography.ErrTokenExpired):
+		writeError(w, http.StatusGone, err)
+	default:
+		s.logger.Error("token manager error", "error", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
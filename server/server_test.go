@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Danikalyk/LoyalKeyPatron/cryptography"
+)
+
+func newTestServer() *Server {
+	tm := cryptography.NewTokenManager(cryptography.NewMemoryTokenJar())
+	return New(&Config{Addr: ":0"}, tm, nil)
+}
+
+func postJSON(t *testing.T, srv *Server, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		t.Fatalf("encoding request body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	rec := httptest.NewRecorder()
+	srv.http.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleIssueKeyAndVerify(t *testing.T) {
+	srv := newTestServer()
+
+	rec := postJSON(t, srv, "/v1/keys", keyRequest{ServiceName: "billing", UserName: "alice"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("issue key: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var keyResp keyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &keyResp); err != nil {
+		t.Fatalf("decoding key response: %v", err)
+	}
+
+	rec = postJSON(t, srv, "/v1/keys/verify", verifyKeyRequest{Key: keyResp.Key})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify key: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var verifyResp verifyKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &verifyResp); err != nil {
+		t.Fatalf("decoding verify response: %v", err)
+	}
+	if verifyResp.ServiceName != "billing" || verifyResp.UserName != "alice" {
+		t.Fatalf("verify response = %+v, want service=billing user=alice", verifyResp)
+	}
+}
+
+func TestHandleVerifyKeyMalformed(t *testing.T) {
+	srv := newTestServer()
+
+	rec := postJSON(t, srv, "/v1/keys/verify", verifyKeyRequest{Key: "not-a-key"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleIssueServiceTokenRequiresName(t *testing.T) {
+	srv := newTestServer()
+
+	rec := postJSON(t, srv, "/v1/tokens/service", serviceTokenRequest{ServiceName: ""})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}